@@ -0,0 +1,58 @@
+// Package codec renders a decoded emdr.Message in whatever wire format a
+// subscriber asked for, wrapped in an envelope carrying the schema
+// version so downstream consumers can evolve independently of the relay.
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+	msgpack "github.com/vmihailenco/msgpack/v5"
+)
+
+// Format identifies an outbound wire encoding, requested by a subscriber
+// via a subscription option or a -format= flag.
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatMsgPack Format = "msgpack"
+)
+
+// SchemaVersion is embedded in every outbound envelope.
+const SchemaVersion = "1"
+
+// ParseFormat parses a -format= flag value or subscription option into a
+// Format, defaulting to JSON for an empty string.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatMsgPack:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("codec: unknown format %q", s)
+	}
+}
+
+// jsonEnvelope is the JSON/MsgPack wire shape: the decoded message plus
+// its schema version. The embedded field must be a value, not a
+// pointer: msgpack's ",inline" tag panics on a pointer-typed anonymous
+// field.
+type jsonEnvelope struct {
+	SchemaVersion string `json:"schemaVersion" msgpack:"schemaVersion"`
+	emdr.Message  `msgpack:",inline"`
+}
+
+// Encode renders msg in the requested format.
+func Encode(format Format, msg *emdr.Message) ([]byte, error) {
+	switch format {
+	case FormatJSON, "":
+		return json.Marshal(jsonEnvelope{SchemaVersion: SchemaVersion, Message: *msg})
+	case FormatMsgPack:
+		return msgpack.Marshal(jsonEnvelope{SchemaVersion: SchemaVersion, Message: *msg})
+	default:
+		return nil, fmt.Errorf("codec: unknown format %q", format)
+	}
+}
@@ -0,0 +1,84 @@
+package codec
+
+import (
+	"encoding/json"
+	"testing"
+
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+	msgpack "github.com/vmihailenco/msgpack/v5"
+)
+
+func sampleMessage() *emdr.Message {
+	return &emdr.Message{
+		Envelope: emdr.Envelope{
+			ResultType: "orders",
+			Version:    "0.2",
+		},
+		OrderRowsets: []emdr.OrderRowset{
+			{RegionID: 10000002, TypeID: 34},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]Format{
+		"":        FormatJSON,
+		"json":    FormatJSON,
+		"msgpack": FormatMsgPack,
+	}
+	for in, want := range cases {
+		got, err := ParseFormat(in)
+		if err != nil {
+			t.Fatalf("ParseFormat(%q): %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("ParseFormat(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"xml", "protobuf"} {
+		if _, err := ParseFormat(in); err == nil {
+			t.Fatalf("ParseFormat(%q): expected an error for an unsupported format", in)
+		}
+	}
+}
+
+func TestEncodeJSONRoundTrip(t *testing.T) {
+	msg := sampleMessage()
+
+	encoded, err := Encode(FormatJSON, msg)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded jsonEnvelope
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.SchemaVersion != SchemaVersion {
+		t.Fatalf("schemaVersion = %q, want %q", decoded.SchemaVersion, SchemaVersion)
+	}
+	if decoded.Envelope.ResultType != msg.Envelope.ResultType {
+		t.Fatalf("resultType = %q, want %q", decoded.Envelope.ResultType, msg.Envelope.ResultType)
+	}
+}
+
+func TestEncodeMsgPackRoundTrip(t *testing.T) {
+	msg := sampleMessage()
+
+	encoded, err := Encode(FormatMsgPack, msg)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded jsonEnvelope
+	if err := msgpack.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.SchemaVersion != SchemaVersion {
+		t.Fatalf("schemaVersion = %q, want %q", decoded.SchemaVersion, SchemaVersion)
+	}
+	if len(decoded.OrderRowsets) != 1 {
+		t.Fatalf("expected 1 order rowset, got %d", len(decoded.OrderRowsets))
+	}
+}
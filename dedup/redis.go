@@ -0,0 +1,37 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore dedups via SETNX-with-TTL, so several emdr-relay-go
+// instances behind the same set of relays can share dedup state instead
+// of each seeing every message as unique.
+type RedisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+	prefix string
+}
+
+// NewRedisStore wraps an already-configured Redis client. ttl should
+// match the desired dedup window.
+func NewRedisStore(client *redis.Client, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: client, ttl: ttl, prefix: "emdr-relay:dedup:"}
+}
+
+func (r *RedisStore) SeenOrMark(key []byte) (bool, error) {
+	set, err := r.client.SetNX(context.Background(), r.prefix+string(key), 1, r.ttl).Result()
+	if err != nil {
+		return false, err
+	}
+	// SetNX reports whether it newly set the key, i.e. whether key was
+	// *not* already seen.
+	return !set, nil
+}
+
+func (r *RedisStore) Close() error {
+	return r.client.Close()
+}
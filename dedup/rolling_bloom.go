@@ -0,0 +1,153 @@
+package dedup
+
+import (
+	"encoding/gob"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	bloom "github.com/bits-and-blooms/bloom/v3"
+)
+
+// RollingConfig configures a RollingBloom store.
+type RollingConfig struct {
+	// Window is how long a key is remembered before it's allowed to
+	// reappear without being treated as a duplicate.
+	Window time.Duration
+	// Shards is how many generational filters make up the rolling
+	// window; one is rotated out (and reset) every Window/Shards.
+	Shards int
+	// ExpectedCardinality is the number of distinct keys expected within
+	// a single shard's lifetime, used to size each bloom filter.
+	ExpectedCardinality uint
+	// FalsePositiveRate is the target false-positive rate of each shard.
+	FalsePositiveRate float64
+}
+
+// DefaultRollingConfig is sized for a single relay instance handling the
+// full EMDR firehose.
+var DefaultRollingConfig = RollingConfig{
+	Window:              5 * time.Minute,
+	Shards:              5,
+	ExpectedCardinality: 100000,
+	FalsePositiveRate:   0.001,
+}
+
+// RollingBloom is the default, in-process Store: a ring of Shards bloom
+// filters, one of which is rotated out every Window/Shards. Querying all
+// shards gives a well-defined dedup window with bounded memory and a
+// tunable false-positive rate, unlike an LRU sized by entry count alone.
+type RollingBloom struct {
+	cfg RollingConfig
+
+	mu      sync.Mutex
+	filters []*bloom.BloomFilter
+	newest  int
+
+	stop chan struct{}
+}
+
+// NewRollingBloom creates and starts a RollingBloom store.
+func NewRollingBloom(cfg RollingConfig) *RollingBloom {
+	r := &RollingBloom{
+		cfg:     cfg,
+		filters: make([]*bloom.BloomFilter, cfg.Shards),
+		stop:    make(chan struct{}),
+	}
+	for i := range r.filters {
+		r.filters[i] = bloom.NewWithEstimates(cfg.ExpectedCardinality, cfg.FalsePositiveRate)
+	}
+	go r.rotateLoop()
+	return r
+}
+
+func (r *RollingBloom) rotateLoop() {
+	interval := r.cfg.Window / time.Duration(r.cfg.Shards)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			r.newest = (r.newest + 1) % len(r.filters)
+			r.filters[r.newest] = bloom.NewWithEstimates(r.cfg.ExpectedCardinality, r.cfg.FalsePositiveRate)
+			r.mu.Unlock()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// SeenOrMark queries the union of all shards, then records key in the
+// current (newest) shard.
+func (r *RollingBloom) SeenOrMark(key []byte) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range r.filters {
+		if f.Test(key) {
+			return true, nil
+		}
+	}
+	r.filters[r.newest].Add(key)
+	return false, nil
+}
+
+// Close stops the shard rotation goroutine.
+func (r *RollingBloom) Close() error {
+	close(r.stop)
+	return nil
+}
+
+// rollingBloomState is the on-disk representation written by SaveToDisk
+// and read back by LoadRollingBloomFromDisk.
+type rollingBloomState struct {
+	Filters []*bloom.BloomFilter
+	Newest  int
+}
+
+// SaveToDisk gob-encodes every shard to path, so a restarted relay can
+// reload recent dedup state with LoadRollingBloomFromDisk instead of
+// starting cold.
+func (r *RollingBloom) SaveToDisk(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	state := rollingBloomState{Filters: r.filters, Newest: r.newest}
+	return gob.NewEncoder(file).Encode(state)
+}
+
+// LoadRollingBloomFromDisk restores a RollingBloom previously saved with
+// SaveToDisk. A missing file just means a cold start, not an error.
+func LoadRollingBloomFromDisk(path string, cfg RollingConfig) (*RollingBloom, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewRollingBloom(cfg), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var state rollingBloomState
+	if err := gob.NewDecoder(file).Decode(&state); err != nil {
+		return nil, err
+	}
+
+	r := &RollingBloom{
+		cfg:     cfg,
+		filters: state.Filters,
+		newest:  state.Newest,
+		stop:    make(chan struct{}),
+	}
+	go r.rotateLoop()
+	return r, nil
+}
@@ -0,0 +1,110 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+)
+
+func testConfig() RollingConfig {
+	return RollingConfig{
+		Window:              50 * time.Millisecond,
+		Shards:              2,
+		ExpectedCardinality: 1000,
+		FalsePositiveRate:   0.001,
+	}
+}
+
+func TestRollingBloomSeenOrMark(t *testing.T) {
+	r := NewRollingBloom(testConfig())
+	defer r.Close()
+
+	key := []byte("key-1")
+
+	seen, err := r.SeenOrMark(key)
+	if err != nil {
+		t.Fatalf("SeenOrMark: %v", err)
+	}
+	if seen {
+		t.Fatal("expected first SeenOrMark to report not seen")
+	}
+
+	seen, err = r.SeenOrMark(key)
+	if err != nil {
+		t.Fatalf("SeenOrMark: %v", err)
+	}
+	if !seen {
+		t.Fatal("expected second SeenOrMark with the same key to report seen")
+	}
+}
+
+func TestRollingBloomExpiresOutsideWindow(t *testing.T) {
+	cfg := testConfig()
+	r := NewRollingBloom(cfg)
+	defer r.Close()
+
+	key := []byte("key-rotates-out")
+	if _, err := r.SeenOrMark(key); err != nil {
+		t.Fatalf("SeenOrMark: %v", err)
+	}
+
+	// Sleep long enough for every shard to rotate at least once, so the
+	// shard holding key is gone.
+	time.Sleep(cfg.Window + cfg.Window/2)
+
+	seen, err := r.SeenOrMark(key)
+	if err != nil {
+		t.Fatalf("SeenOrMark: %v", err)
+	}
+	if seen {
+		t.Fatal("expected key to have rotated out of the dedup window")
+	}
+}
+
+func TestRollingBloomSaveAndLoadFromDisk(t *testing.T) {
+	cfg := testConfig()
+	r := NewRollingBloom(cfg)
+
+	key := []byte("persisted-key")
+	if _, err := r.SeenOrMark(key); err != nil {
+		t.Fatalf("SeenOrMark: %v", err)
+	}
+
+	path := t.TempDir() + "/dedup.gob"
+	if err := r.SaveToDisk(path); err != nil {
+		t.Fatalf("SaveToDisk: %v", err)
+	}
+	r.Close()
+
+	restored, err := LoadRollingBloomFromDisk(path, cfg)
+	if err != nil {
+		t.Fatalf("LoadRollingBloomFromDisk: %v", err)
+	}
+	defer restored.Close()
+
+	seen, err := restored.SeenOrMark(key)
+	if err != nil {
+		t.Fatalf("SeenOrMark: %v", err)
+	}
+	if !seen {
+		t.Fatal("expected key persisted before restart to still be seen")
+	}
+}
+
+func TestLoadRollingBloomFromDiskMissingFileStartsCold(t *testing.T) {
+	cfg := testConfig()
+	path := t.TempDir() + "/does-not-exist.gob"
+
+	r, err := LoadRollingBloomFromDisk(path, cfg)
+	if err != nil {
+		t.Fatalf("LoadRollingBloomFromDisk: %v", err)
+	}
+	defer r.Close()
+
+	seen, err := r.SeenOrMark([]byte("anything"))
+	if err != nil {
+		t.Fatalf("SeenOrMark: %v", err)
+	}
+	if seen {
+		t.Fatal("expected a cold start to not have seen anything yet")
+	}
+}
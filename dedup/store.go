@@ -0,0 +1,36 @@
+// Package dedup decides whether a message has already been relayed.
+package dedup
+
+import (
+	"encoding/binary"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Store reports whether a message has been seen before, and marks it as
+// seen if not. Implementations must be safe for concurrent use.
+type Store interface {
+	// SeenOrMark returns true if key was already recorded, and records it
+	// if not, in one check-and-set step.
+	SeenOrMark(key []byte) (seen bool, err error)
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+// Persistent is implemented by Store implementations that can save their
+// state to disk, so a restarted relay doesn't forget recent dedup state
+// and re-broadcast a burst of messages its upstream relays resend.
+type Persistent interface {
+	SaveToDisk(path string) error
+}
+
+// HashKey reduces a raw message to the key a Store dedups on. The old
+// relay hashed with 32-bit FNV, which only has ~4 billion possible
+// values; at the relay's message volume that produces enough collisions
+// to silently drop distinct market messages. xxhash's 64-bit output
+// makes that effectively impossible.
+func HashKey(raw []byte) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, xxhash.Sum64(raw))
+	return key
+}
@@ -1,200 +1,261 @@
 package main
 
 import (
-	"bytes"
-	"compress/zlib"
-	"fmt"
-	couchbase "github.com/couchbase/go-couchbase"
-	cache "github.com/gtaylor/emdr-relay-go/cache"
-	zmq "github.com/pebbe/zmq4"
-	"hash"
-	"hash/fnv"
-	"io/ioutil"
+	"context"
 	"encoding/json"
+	couchbase "github.com/couchbase/go-couchbase"
+	dedup "github.com/gtaylor/emdr-relay-go/dedup"
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+	httpapi "github.com/gtaylor/emdr-relay-go/httpapi"
+	metrics "github.com/gtaylor/emdr-relay-go/metrics"
+	relay "github.com/gtaylor/emdr-relay-go/relay"
+	redis "github.com/redis/go-redis/v9"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
-	"unsafe"
 )
 
-// The presence of the cache value is all we need, so keep this super simple.
-type CacheValue struct {
-	found bool
-}
-
-type EMDRMsg struct {
-	ResultType string `json:"resultType"`
-	Version    string `json:"version"`
-	UploadKeys []struct {
-		Name string `json:"name"`
-		Key  string `json:"key"`
-	} `json:"uploadKeys"`
-	Generator struct {
-		Name    string `json:"name"`
-		Version string `json:"version"`
-	} `json:"generator"`
-	CurrentTime time.Time `json:"currentTime"`
-	Columns     []string  `json:"columns"`
-	Rowsets     []struct {
-		GeneratedAt time.Time `json:"generatedAt"`
-		RegionID    int       `json:"regionID"`
-		TypeID      int       `json:"typeID"`
-		Rows        []struct {
-			Num0  int       `json:"0"`
-			Num1  int       `json:"1"`
-			Num2  int       `json:"2"`
-			Num3  int64     `json:"3"`
-			Num4  int       `json:"4"`
-			Num5  int       `json:"5"`
-			Num6  bool      `json:"6"`
-			Num7  time.Time `json:"7"`
-			Num8  int       `json:"8"`
-			Num9  int       `json:"9"`
-			Num10 int       `json:"10"`
-		} `json:"rows"`
-	} `json:"rowsets"`
-}
-
 type Configuration struct {
 	URI            string   `json:"URI"`
 	Cluster        string   `json:"Cluster"`
 	Bucket         string   `json:"Bucket"`
 	RelayList      []string `json:"RelayList"`
 	CouchbaseCache bool     `json:"CouchbaseCache"`
+	// Backends lists the relay.Backend implementations to register, by
+	// name (see registerBackends). If empty, we fall back to "zmq", plus
+	// "couchbase" when CouchbaseCache is set, to match the relay's
+	// historical behavior.
+	Backends      []string `json:"Backends"`
+	FileRotateDir string   `json:"FileRotateDir"`
+
+	// DedupRedisAddr, if set, switches dedup to a Redis-backed
+	// dedup.Store so several relay instances can share dedup state.
+	// Otherwise dedup is an in-process rolling Bloom filter.
+	DedupRedisAddr           string  `json:"DedupRedisAddr"`
+	DedupWindowSeconds       int     `json:"DedupWindowSeconds"`
+	DedupShards              int     `json:"DedupShards"`
+	DedupExpectedCardinality uint    `json:"DedupExpectedCardinality"`
+	DedupFalsePositiveRate   float64 `json:"DedupFalsePositiveRate"`
+	// DedupPersistPath, if set and the dedup store supports it, is where
+	// dedup state is flushed on graceful shutdown and reloaded from on
+	// startup, so a restart doesn't cause a burst of re-broadcasts.
+	DedupPersistPath string `json:"DedupPersistPath"`
 }
 
-type EMDRDoc struct {
-	Region     int `json:"region"`
-	ItemID     int `json:"ItemID"`
-	InsertTime int `json:"InsertTime"`
-	UploadKeys []struct {
-		Name string `json:"name"`
-		Key  string `json:"key"`
+// dedupWindow returns the configured dedup window, or the rolling Bloom
+// default if unset.
+func dedupWindow(configuration Configuration) time.Duration {
+	if configuration.DedupWindowSeconds == 0 {
+		return dedup.DefaultRollingConfig.Window
 	}
-	ResultType string `json:"resultType"`
+	return time.Duration(configuration.DedupWindowSeconds) * time.Second
 }
 
-//Calculate the size (in bytes) of our struct.
-const cache_value_size = int64(unsafe.Sizeof(CacheValue{}))
+// buildDedupStore constructs the dedup.Store named by configuration:
+// Redis when DedupRedisAddr is set, otherwise an in-process rolling
+// Bloom filter sized from the Dedup* fields (falling back to
+// dedup.DefaultRollingConfig for anything left unset).
+func buildDedupStore(configuration Configuration) dedup.Store {
+	if configuration.DedupRedisAddr != "" {
+		client := redis.NewClient(&redis.Options{Addr: configuration.DedupRedisAddr})
+		return dedup.NewRedisStore(client, dedupWindow(configuration))
+	}
+
+	cfg := dedup.DefaultRollingConfig
+	cfg.Window = dedupWindow(configuration)
+	if configuration.DedupShards > 0 {
+		cfg.Shards = configuration.DedupShards
+	}
+	if configuration.DedupExpectedCardinality > 0 {
+		cfg.ExpectedCardinality = configuration.DedupExpectedCardinality
+	}
+	if configuration.DedupFalsePositiveRate > 0 {
+		cfg.FalsePositiveRate = configuration.DedupFalsePositiveRate
+	}
+
+	if configuration.DedupPersistPath != "" {
+		store, err := dedup.LoadRollingBloomFromDisk(configuration.DedupPersistPath, cfg)
+		if err != nil {
+			slog.Error("failed to load persisted dedup state, starting cold", "path", configuration.DedupPersistPath, "err", err)
+			return dedup.NewRollingBloom(cfg)
+		}
+		return store
+	}
+	return dedup.NewRollingBloom(cfg)
+}
+
+// hubBackend adapts httpapi.Hub to relay.Backend so the reactor can
+// dispatch decoded messages to WebSocket subscribers the same way it does
+// to every other backend.
+type hubBackend struct {
+	hub *httpapi.Hub
+}
+
+func (h *hubBackend) Name() string {
+	return "websocket-hub"
+}
+
+// Close shuts down the hub, closing every subscriber's connection.
+func (h *hubBackend) Close() error {
+	h.hub.Shutdown()
+	return nil
+}
 
-// Determines the max cache size, in bytes.
-const cache_size_limit = cache_value_size * 1000
+func (h *hubBackend) Consume(msg *emdr.Message, raw []byte) error {
+	for _, rs := range msg.OrderRowsets {
+		h.hub.Publish(httpapi.Message{
+			Decoded:    msg,
+			ResultType: msg.Envelope.ResultType,
+			RegionID:   rs.RegionID,
+			TypeID:     rs.TypeID,
+		})
+	}
+	for _, rs := range msg.HistoryRowsets {
+		h.hub.Publish(httpapi.Message{
+			Decoded:    msg,
+			ResultType: msg.Envelope.ResultType,
+			RegionID:   rs.RegionID,
+			TypeID:     rs.TypeID,
+		})
+	}
+	return nil
+}
 
-// Satisfies the Value interface.
-func (self *CacheValue) Size() int {
-	return int(cache_value_size)
+func backendNames(configuration Configuration) []string {
+	if len(configuration.Backends) > 0 {
+		return configuration.Backends
+	}
+	names := []string{"zmq"}
+	if configuration.CouchbaseCache {
+		names = append(names, "couchbase")
+	}
+	return names
 }
 
-func periodic_exiter() {
-	// We exit periodically so that the process supervisor can restart us.
-	// This helps recover from some edge cases where connections to the
-	// announcers aren't picked back up.
-	// Currently hardcoded to every 12 hours.
-	ticker := time.NewTicker(12 * 3600 * time.Second)
-	for {
-		select {
-		case <-ticker.C:
-			println("Exiting so we can auto-restart.")
-			os.Exit(0)
+// registerBackends wires up the relay.Backend implementations named in
+// configuration.Backends. The WebSocket hub is always registered, since
+// /subscribe is part of the core relay rather than an optional sink.
+func registerBackends(r *relay.Reactor, configuration Configuration, hub *httpapi.Hub, bucket *couchbase.Bucket) {
+	r.Register(&hubBackend{hub: hub})
+
+	for _, name := range backendNames(configuration) {
+		switch name {
+		case "zmq":
+			pub, err := relay.NewZMQPublisher("tcp://*:8050")
+			if err != nil {
+				slog.Error("zmq backend error", "err", err)
+				continue
+			}
+			r.Register(pub)
+		case "couchbase":
+			if bucket == nil {
+				slog.Warn("couchbase backend requested but bucket is unavailable")
+				continue
+			}
+			r.Register(relay.NewCouchbaseWriter(bucket))
+		case "stdout-json":
+			r.Register(relay.StdoutJSON{})
+		case "file-rotation":
+			r.Register(relay.NewFileRotator(configuration.FileRotateDir))
+		case "benchmark":
+			r.Register(&relay.Benchmarker{})
+		default:
+			slog.Warn("unknown backend", "name", name)
 		}
 	}
 }
 
 func main() {
-	println("=====================[ emdr-relay-go ]=====================")
-	println("Starting emdr-relay-go 1.1...")
-	cache := cache.NewLRUCache(cache_size_limit)
+	slog.Info("starting emdr-relay-go", "version", "1.1")
 
 	file, _ := os.Open("config.json")
 	decoder := json.NewDecoder(file)
 	configuration := Configuration{}
 	err := decoder.Decode(&configuration)
 	if err != nil {
-		fmt.Println("error:", err)
+		slog.Error("failed to decode config.json", "err", err)
 	}
 
-	b, err := couchbase.GetBucket(configuration.URI, configuration.Cluster, configuration.Bucket)
-	receiver, _ := zmq.NewSocket(zmq.SUB)
-	for _, relay := range Configuration.RelayList {
-		receiver.Connect(relay)
-	}
-	receiver.SetSubscribe("")
-	defer receiver.Close()
+	health := metrics.NewHealth(configuration.CouchbaseCache)
 
-	sender, _ := zmq.NewSocket(zmq.PUB)
-	sender.Bind("tcp://*:8050")
-	defer sender.Close()
+	var bucket *couchbase.Bucket
+	if configuration.CouchbaseCache {
+		b, err := couchbase.GetBucket(configuration.URI, configuration.Cluster, configuration.Bucket)
+		if err != nil {
+			slog.Error("couchbase connect failed", "err", err)
+		} else {
+			bucket = b
+			health.SetCouchbaseConnected(true)
+		}
+	}
 
-	println("Listening on port 8050.")
-	println("===========================================================")
-	//  Ensure subscriber connection has time to complete
-	time.Sleep(time.Second)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// We auto-exit every number of hours so we can recover from some
-	// weird edge case conditions that disrupt the network. They're not common,
-	// but we'll do this to be absolutely sure.
-	go periodic_exiter()
+	// Each relay URL gets its own supervised ZMQ SUB connection, so a
+	// stale or dropped connection to one relay gets reconnected on its
+	// own instead of requiring the whole process to exit and restart.
+	// Each supervisor reports its own connect/disconnect/reconnect events
+	// back into health, so /readyz reflects real connection state rather
+	// than a static check of the configured relay list.
+	frames := make(chan []byte, relay.QueueSize)
+	for _, relayURL := range configuration.RelayList {
+		relayURL := relayURL
+		sup := relay.NewSupervisor(relayURL, frames, func(connected bool) {
+			health.SetRelayConnected(relayURL, connected)
+		})
+		go sup.Run(ctx)
+	}
 
-	for {
-		msg, zmq_err := receiver.Recv(0)
-		if zmq_err != nil {
-			println("RECV ERROR:", zmq_err.Error())
-		}
+	// Every deduplicated message gets fanned out to WebSocket subscribers
+	// of the /subscribe endpoint, alongside whatever backends are
+	// registered below.
+	hub := httpapi.NewHub()
+	go hub.Run()
+	go http.ListenAndServe(":8051", httpapi.NewServeMux(hub))
 
-		var h hash.Hash = fnv.New32()
-		h.Write([]byte(msg))
+	// /metrics, /healthz, and /readyz for operators.
+	go http.ListenAndServe(":8052", metrics.NewServeMux(health))
 
-		checksum := h.Sum([]byte{})
-		cache_key := fmt.Sprintf("%x", checksum)
+	store := buildDedupStore(configuration)
+	reactor := relay.NewReactor(store)
+	registerBackends(reactor, configuration, hub, bucket)
+	reactor.Start()
 
-		cache_item, cache_hit := cache.Get(cache_key)
-		if cache_hit {
-			// We've already seen this before, ignore it.
-			continue
-		}
+	slog.Info("listening", "websocket", 8051, "metrics", 8052)
 
-		// At this point, we know we've encountered a message we haven't
-		// seen in the recent past.
-		cache_item = &CacheValue{found: true}
-		// Insert the cache entry to prevent future re-sends of this message.
-		cache.Set(cache_key, cache_item)
-
-		// A cache miss means that the incoming message is not a dupe.
-		// Send the message to subscribers.
-		sender.Send(msg, 0)
-		if Configuration.CouchbaseCache == false {
-			continue
-		}
-		var m EMDRMsg
-		decoded, err := ZlibDecode(msg)
-		if err != nil {
-			log.Fatal(err)
-		}
-		err := json.Unmarshal(decoded, &m)
-		for _, element := range m.Rowsets {
-			if element.GeneratedAt.Unix() >= int32(time.Now().Unix())-3600 {
-				val := EMDRDoc{element.RegionID, element.TypeID, int32(time.Now().Unix()), m.UploadKeys, m.ResultType}
-				var buffer bytes.Buffer
-				region_string, _ := strconv.Itoa(element.RegionID)
-				type_string, _ := strconv.Itoa(element.TypeID)
-				buffer.WriteString(region_string)
-				buffer.WriteString("-")
-				buffer.WriteString(type_string)
-				buffer.WriteString("-")
-				buffer.WriteString(m.ResultType)
-				b.Set(buffer.String(), val)
+	dispatchDone := make(chan struct{})
+	go func() {
+		defer close(dispatchDone)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case raw := <-frames:
+				if _, err := reactor.Dispatch(raw); err != nil {
+					slog.Error("dispatch failed", "err", err)
+				}
 			}
 		}
-	}
-}
+	}()
+
+	<-ctx.Done()
+	slog.Info("shutting down")
 
-func ZlibDecode(encoded string) (decoded []byte, err error) {
-	b := bytes.NewBufferString(encoded)
-	pipeline, err := zlib.NewReader(b)
+	<-dispatchDone
+	reactor.Stop()
 
-	if err == nil {
-		defer pipeline.Close()
-		decoded, err = ioutil.ReadAll(pipeline)
+	if persister, ok := store.(dedup.Persistent); ok && configuration.DedupPersistPath != "" {
+		if err := persister.SaveToDisk(configuration.DedupPersistPath); err != nil {
+			slog.Error("failed to persist dedup state", "path", configuration.DedupPersistPath, "err", err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		slog.Error("failed to close dedup store", "err", err)
 	}
 
-	return
+	slog.Info("shutdown complete")
 }
@@ -0,0 +1,83 @@
+package emdr
+
+import (
+	"fmt"
+	"time"
+)
+
+func column(row []interface{}, idx map[string]int, name string) (interface{}, bool) {
+	i, ok := idx[name]
+	if !ok || i >= len(row) {
+		return nil, false
+	}
+	return row[i], true
+}
+
+func float64Column(row []interface{}, idx map[string]int, name string) (float64, error) {
+	v, ok := column(row, idx, name)
+	if !ok {
+		return 0, fmt.Errorf("missing column %q", name)
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("column %q: expected number, got %T", name, v)
+	}
+	return f, nil
+}
+
+func int64Column(row []interface{}, idx map[string]int, name string) (int64, error) {
+	f, err := float64Column(row, idx, name)
+	if err != nil {
+		return 0, err
+	}
+	return int64(f), nil
+}
+
+func intColumn(row []interface{}, idx map[string]int, name string) (int, error) {
+	v, err := int64Column(row, idx, name)
+	return int(v), err
+}
+
+func stringColumn(row []interface{}, idx map[string]int, name string) (string, error) {
+	v, ok := column(row, idx, name)
+	if !ok {
+		return "", fmt.Errorf("missing column %q", name)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("column %q: expected string, got %T", name, v)
+	}
+	return s, nil
+}
+
+func boolColumn(row []interface{}, idx map[string]int, name string) (bool, error) {
+	v, ok := column(row, idx, name)
+	if !ok {
+		return false, fmt.Errorf("missing column %q", name)
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("column %q: expected bool, got %T", name, v)
+	}
+	return b, nil
+}
+
+// timeColumn parses a column carrying an EMDR "issueDate"-style
+// timestamp: "YYYY-MM-DD HH:MM:SS".
+func timeColumn(row []interface{}, idx map[string]int, name string) (time.Time, error) {
+	s, err := stringColumn(row, idx, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse("2006-01-02 15:04:05", s)
+}
+
+// dateColumn parses a column carrying an EMDR "date"-style date-only
+// value: "YYYY-MM-DD".
+func dateColumn(row []interface{}, idx map[string]int, name string) (time.Time, error) {
+	s, err := stringColumn(row, idx, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,27 @@
+package emdr
+
+func decodeHistoryRow(idx map[string]int, row []interface{}) (HistoryRow, error) {
+	var r HistoryRow
+	var err error
+
+	if r.Date, err = dateColumn(row, idx, "date"); err != nil {
+		return r, err
+	}
+	if r.OrderCount, err = intColumn(row, idx, "orderCount"); err != nil {
+		return r, err
+	}
+	if r.LowPrice, err = float64Column(row, idx, "lowPrice"); err != nil {
+		return r, err
+	}
+	if r.HighPrice, err = float64Column(row, idx, "highPrice"); err != nil {
+		return r, err
+	}
+	if r.AvgPrice, err = float64Column(row, idx, "avgPrice"); err != nil {
+		return r, err
+	}
+	if r.Volume, err = int64Column(row, idx, "volume"); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,47 @@
+package emdr
+
+func decodeOrderRow(idx map[string]int, row []interface{}) (OrderRow, error) {
+	var r OrderRow
+	var err error
+
+	if r.Price, err = float64Column(row, idx, "price"); err != nil {
+		return r, err
+	}
+	if r.VolRemaining, err = int64Column(row, idx, "volRemaining"); err != nil {
+		return r, err
+	}
+	if r.Range, err = stringColumn(row, idx, "range"); err != nil {
+		return r, err
+	}
+	if r.OrderID, err = int64Column(row, idx, "orderID"); err != nil {
+		return r, err
+	}
+	if r.VolEntered, err = int64Column(row, idx, "volEntered"); err != nil {
+		return r, err
+	}
+	if r.MinVolume, err = int64Column(row, idx, "minVolume"); err != nil {
+		return r, err
+	}
+	if r.Bid, err = boolColumn(row, idx, "bid"); err != nil {
+		return r, err
+	}
+	if r.IssueDate, err = timeColumn(row, idx, "issueDate"); err != nil {
+		return r, err
+	}
+	if r.Duration, err = intColumn(row, idx, "duration"); err != nil {
+		return r, err
+	}
+	if r.StationID, err = int64Column(row, idx, "stationID"); err != nil {
+		return r, err
+	}
+
+	// solarSystemID isn't in every uploader's columns list; tolerate its
+	// absence instead of failing the whole row.
+	if v, ok := column(row, idx, "solarSystemID"); ok {
+		if f, ok := v.(float64); ok {
+			r.SolarSystemID = int64(f)
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,152 @@
+// Package emdr decodes the EMDR Unified Uploader Data Interchange Format
+// (UUDIF). Rows are honored via the upload's columns array rather than a
+// fixed field order, and "orders" and "history" resultType uploads are
+// decoded into their own distinct row types.
+package emdr
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// UploadKey identifies the uploader client/community that produced a
+// message.
+type UploadKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// Generator identifies the software that generated an upload.
+type Generator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Envelope is everything in a UUDIF upload that doesn't depend on
+// resultType.
+type Envelope struct {
+	ResultType  string      `json:"resultType"`
+	Version     string      `json:"version"`
+	UploadKeys  []UploadKey `json:"uploadKeys"`
+	Generator   Generator   `json:"generator"`
+	CurrentTime time.Time   `json:"currentTime"`
+	Columns     []string    `json:"columns"`
+}
+
+// OrderRow is one row of an "orders" resultType rowset.
+type OrderRow struct {
+	Price         float64   `json:"price" msgpack:"price"`
+	VolRemaining  int64     `json:"volRemaining" msgpack:"volRemaining"`
+	Range         string    `json:"range" msgpack:"range"`
+	OrderID       int64     `json:"orderID" msgpack:"orderID"`
+	VolEntered    int64     `json:"volEntered" msgpack:"volEntered"`
+	MinVolume     int64     `json:"minVolume" msgpack:"minVolume"`
+	Bid           bool      `json:"bid" msgpack:"bid"`
+	IssueDate     time.Time `json:"issueDate" msgpack:"issueDate"`
+	Duration      int       `json:"duration" msgpack:"duration"`
+	StationID     int64     `json:"stationID" msgpack:"stationID"`
+	SolarSystemID int64     `json:"solarSystemID" msgpack:"solarSystemID"`
+}
+
+// HistoryRow is one row of a "history" resultType rowset.
+type HistoryRow struct {
+	Date       time.Time `json:"date" msgpack:"date"`
+	OrderCount int       `json:"orderCount" msgpack:"orderCount"`
+	LowPrice   float64   `json:"lowPrice" msgpack:"lowPrice"`
+	HighPrice  float64   `json:"highPrice" msgpack:"highPrice"`
+	AvgPrice   float64   `json:"avgPrice" msgpack:"avgPrice"`
+	Volume     int64     `json:"volume" msgpack:"volume"`
+}
+
+// OrderRowset is one rowset of an "orders" upload: every row shares a
+// RegionID/TypeID/GeneratedAt.
+type OrderRowset struct {
+	GeneratedAt time.Time  `json:"generatedAt" msgpack:"generatedAt"`
+	RegionID    int        `json:"regionID" msgpack:"regionID"`
+	TypeID      int        `json:"typeID" msgpack:"typeID"`
+	Rows        []OrderRow `json:"rows" msgpack:"rows"`
+}
+
+// HistoryRowset is one rowset of a "history" upload.
+type HistoryRowset struct {
+	GeneratedAt time.Time    `json:"generatedAt" msgpack:"generatedAt"`
+	RegionID    int          `json:"regionID" msgpack:"regionID"`
+	TypeID      int          `json:"typeID" msgpack:"typeID"`
+	Rows        []HistoryRow `json:"rows" msgpack:"rows"`
+}
+
+// Message is a fully decoded UUDIF upload. Exactly one of OrderRowsets or
+// HistoryRowsets is populated, matching Envelope.ResultType.
+type Message struct {
+	Envelope       Envelope        `json:"envelope" msgpack:"envelope"`
+	OrderRowsets   []OrderRowset   `json:"orderRowsets,omitempty" msgpack:"orderRowsets,omitempty"`
+	HistoryRowsets []HistoryRowset `json:"historyRowsets,omitempty" msgpack:"historyRowsets,omitempty"`
+}
+
+// wireEnvelope mirrors the upload's on-the-wire shape: each row arrives
+// as an array of values, positioned according to Columns rather than
+// fixed struct fields.
+type wireEnvelope struct {
+	Envelope
+	Rowsets []wireRowset `json:"rowsets"`
+}
+
+type wireRowset struct {
+	GeneratedAt time.Time       `json:"generatedAt"`
+	RegionID    int             `json:"regionID"`
+	TypeID      int             `json:"typeID"`
+	Rows        [][]interface{} `json:"rows"`
+}
+
+// Decode parses a zlib-decompressed UUDIF payload. It honors Columns to
+// locate each field within a row, so a change in column order doesn't
+// silently corrupt data the way a positional struct used to.
+func Decode(decoded []byte) (*Message, error) {
+	var wire wireEnvelope
+	if err := json.Unmarshal(decoded, &wire); err != nil {
+		return nil, err
+	}
+
+	idx := columnIndex(wire.Columns)
+	msg := &Message{Envelope: wire.Envelope}
+
+	switch wire.ResultType {
+	case "orders":
+		for _, rs := range wire.Rowsets {
+			ors := OrderRowset{GeneratedAt: rs.GeneratedAt, RegionID: rs.RegionID, TypeID: rs.TypeID}
+			for _, row := range rs.Rows {
+				r, err := decodeOrderRow(idx, row)
+				if err != nil {
+					return nil, fmt.Errorf("emdr: decode order row: %w", err)
+				}
+				ors.Rows = append(ors.Rows, r)
+			}
+			msg.OrderRowsets = append(msg.OrderRowsets, ors)
+		}
+	case "history":
+		for _, rs := range wire.Rowsets {
+			hrs := HistoryRowset{GeneratedAt: rs.GeneratedAt, RegionID: rs.RegionID, TypeID: rs.TypeID}
+			for _, row := range rs.Rows {
+				r, err := decodeHistoryRow(idx, row)
+				if err != nil {
+					return nil, fmt.Errorf("emdr: decode history row: %w", err)
+				}
+				hrs.Rows = append(hrs.Rows, r)
+			}
+			msg.HistoryRowsets = append(msg.HistoryRowsets, hrs)
+		}
+	default:
+		return nil, fmt.Errorf("emdr: unsupported resultType %q", wire.ResultType)
+	}
+
+	return msg, nil
+}
+
+func columnIndex(columns []string) map[string]int {
+	idx := make(map[string]int, len(columns))
+	for i, c := range columns {
+		idx[c] = i
+	}
+	return idx
+}
@@ -0,0 +1,92 @@
+package emdr
+
+import "testing"
+
+func TestDecodeOrders(t *testing.T) {
+	payload := []byte(`{
+		"resultType": "orders",
+		"version": "0.2",
+		"uploadKeys": [{"name": "test", "key": "abc"}],
+		"generator": {"name": "test-uploader", "version": "1.0"},
+		"currentTime": "2026-07-26T00:00:00Z",
+		"columns": ["price", "volRemaining", "range", "orderID", "volEntered", "minVolume", "bid", "issueDate", "duration", "stationID", "solarSystemID"],
+		"rowsets": [{
+			"generatedAt": "2026-07-26T00:00:00Z",
+			"regionID": 10000002,
+			"typeID": 34,
+			"rows": [
+				[5.95, 1000, "region", 123456, 1000, 1, false, "2026-07-25 12:00:00", 90, 60003760, 30000142]
+			]
+		}]
+	}`)
+
+	msg, err := Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(msg.OrderRowsets) != 1 {
+		t.Fatalf("expected 1 order rowset, got %d", len(msg.OrderRowsets))
+	}
+	rs := msg.OrderRowsets[0]
+	if rs.RegionID != 10000002 || rs.TypeID != 34 {
+		t.Fatalf("unexpected rowset identity: %+v", rs)
+	}
+	if len(rs.Rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rs.Rows))
+	}
+	row := rs.Rows[0]
+	if row.Price != 5.95 || row.OrderID != 123456 || row.StationID != 60003760 || row.SolarSystemID != 30000142 {
+		t.Fatalf("unexpected decoded row: %+v", row)
+	}
+}
+
+func TestDecodeHistory(t *testing.T) {
+	payload := []byte(`{
+		"resultType": "history",
+		"version": "0.2",
+		"uploadKeys": [],
+		"generator": {"name": "test-uploader", "version": "1.0"},
+		"currentTime": "2026-07-26T00:00:00Z",
+		"columns": ["date", "orderCount", "lowPrice", "highPrice", "avgPrice", "volume"],
+		"rowsets": [{
+			"generatedAt": "2026-07-26T00:00:00Z",
+			"regionID": 10000002,
+			"typeID": 34,
+			"rows": [
+				["2026-07-25", 12, 5.0, 6.5, 5.75, 100000]
+			]
+		}]
+	}`)
+
+	msg, err := Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(msg.HistoryRowsets) != 1 {
+		t.Fatalf("expected 1 history rowset, got %d", len(msg.HistoryRowsets))
+	}
+	row := msg.HistoryRowsets[0].Rows[0]
+	if row.OrderCount != 12 || row.Volume != 100000 || row.AvgPrice != 5.75 {
+		t.Fatalf("unexpected decoded row: %+v", row)
+	}
+}
+
+func TestDecodeUnsupportedResultType(t *testing.T) {
+	payload := []byte(`{"resultType": "bogus", "columns": [], "rowsets": []}`)
+
+	if _, err := Decode(payload); err == nil {
+		t.Fatal("expected an error for an unsupported resultType")
+	}
+}
+
+func TestDecodeMissingColumn(t *testing.T) {
+	payload := []byte(`{
+		"resultType": "orders",
+		"columns": ["price"],
+		"rowsets": [{"regionID": 1, "typeID": 2, "rows": [[5.95]]}]
+	}`)
+
+	if _, err := Decode(payload); err == nil {
+		t.Fatal("expected an error for a row missing a required column")
+	}
+}
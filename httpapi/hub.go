@@ -0,0 +1,205 @@
+// Package httpapi exposes the relay's deduplicated EMDR stream over
+// WebSockets, alongside the existing ZMQ PUB socket.
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+
+	codec "github.com/gtaylor/emdr-relay-go/codec"
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+	metrics "github.com/gtaylor/emdr-relay-go/metrics"
+	"github.com/gorilla/websocket"
+)
+
+// SubscriberBufferSize bounds how many pending messages we'll queue for a
+// single WebSocket client before we start dropping. Slow consumers shouldn't
+// be able to stall delivery to everyone else.
+const SubscriberBufferSize = 64
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// Message is a decoded EMDR upload along with the fields a Filter is
+// evaluated against, so subscribers don't each have to re-parse the JSON.
+// RegionID/TypeID/ResultType describe one rowset within Decoded; a
+// multi-rowset upload is published once per rowset.
+type Message struct {
+	Decoded    *emdr.Message
+	ResultType string
+	RegionID   int
+	TypeID     int
+}
+
+// Filter narrows the firehose down to the rowsets a subscriber actually
+// wants, evaluated after ZlibDecode+JSON parse so clients on constrained
+// links don't have to receive the full firehose.
+type Filter struct {
+	RegionID   int
+	TypeID     int
+	ResultType string
+}
+
+// Match reports whether a message with the given fields should be
+// delivered to a subscriber with this filter. Zero values are wildcards.
+func (f Filter) Match(msg Message) bool {
+	if f.ResultType != "" && f.ResultType != msg.ResultType {
+		return false
+	}
+	if f.RegionID != 0 && f.RegionID != msg.RegionID {
+		return false
+	}
+	if f.TypeID != 0 && f.TypeID != msg.TypeID {
+		return false
+	}
+	return true
+}
+
+type subscriber struct {
+	out    chan []byte
+	filter Filter
+	format codec.Format
+}
+
+// Hub fans decoded messages out to any number of WebSocket subscribers,
+// encoding each in the format that subscriber asked for. A subscriber
+// that can't keep up has messages dropped for it, rather than blocking
+// delivery to everyone else or blocking the publisher.
+type Hub struct {
+	register   chan *subscriber
+	unregister chan *subscriber
+	publish    chan Message
+	shutdown   chan struct{}
+
+	subscribers map[*subscriber]bool
+}
+
+// NewHub creates a Hub. Run must be started in its own goroutine before
+// subscribers are served.
+func NewHub() *Hub {
+	return &Hub{
+		register:    make(chan *subscriber),
+		unregister:  make(chan *subscriber),
+		publish:     make(chan Message, SubscriberBufferSize),
+		shutdown:    make(chan struct{}),
+		subscribers: make(map[*subscriber]bool),
+	}
+}
+
+// Run drives the hub's event loop. It's the only goroutine that ever
+// touches h.subscribers, so no locking is needed. Run returns once
+// Shutdown has closed every subscriber's connection.
+func (h *Hub) Run() {
+	for {
+		select {
+		case sub := <-h.register:
+			h.subscribers[sub] = true
+			metrics.SubscriberCount.Inc()
+		case sub := <-h.unregister:
+			if _, ok := h.subscribers[sub]; ok {
+				delete(h.subscribers, sub)
+				close(sub.out)
+				metrics.SubscriberCount.Dec()
+			}
+		case msg := <-h.publish:
+			for sub := range h.subscribers {
+				if !sub.filter.Match(msg) {
+					continue
+				}
+				encoded, err := codec.Encode(sub.format, msg.Decoded)
+				if err != nil {
+					slog.Error("httpapi: encode failed", "format", sub.format, "err", err)
+					continue
+				}
+				select {
+				case sub.out <- encoded:
+				default:
+					metrics.SubscriberDropped.Inc()
+				}
+			}
+		case <-h.shutdown:
+			for sub := range h.subscribers {
+				delete(h.subscribers, sub)
+				close(sub.out)
+				metrics.SubscriberCount.Dec()
+			}
+			return
+		}
+	}
+}
+
+// Publish queues msg for fan-out to matching subscribers.
+func (h *Hub) Publish(msg Message) {
+	h.publish <- msg
+}
+
+// Shutdown closes every subscriber's connection and stops Run. Subscribe
+// calls already in flight notice their sub.out channel close and return.
+func (h *Hub) Shutdown() {
+	close(h.shutdown)
+}
+
+// filterFromQuery builds a Filter from ?regionID=&typeID=&resultType= params.
+func filterFromQuery(r *http.Request) Filter {
+	q := r.URL.Query()
+	f := Filter{ResultType: q.Get("resultType")}
+	f.RegionID = atoiOrZero(q.Get("regionID"))
+	f.TypeID = atoiOrZero(q.Get("typeID"))
+	return f
+}
+
+// Subscribe upgrades the request to a WebSocket and streams hub messages
+// matching the request's filter and ?format= params until the client
+// disconnects.
+func (h *Hub) Subscribe(w http.ResponseWriter, r *http.Request) {
+	format, err := codec.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sub := &subscriber{
+		out:    make(chan []byte, SubscriberBufferSize),
+		filter: filterFromQuery(r),
+		format: format,
+	}
+	h.register <- sub
+	defer func() { h.unregister <- sub }()
+
+	// Drain and discard anything the client sends us; we only care about
+	// detecting when it goes away.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	messageType := websocket.TextMessage
+	if format != codec.FormatJSON {
+		messageType = websocket.BinaryMessage
+	}
+
+	for encoded := range sub.out {
+		if err := conn.WriteMessage(messageType, encoded); err != nil {
+			return
+		}
+	}
+}
+
+// NewServeMux returns an http.ServeMux with /subscribe wired to the hub.
+func NewServeMux(h *Hub) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/subscribe", h.Subscribe)
+	return mux
+}
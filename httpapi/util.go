@@ -0,0 +1,13 @@
+package httpapi
+
+import "strconv"
+
+// atoiOrZero parses s as an int, treating anything unparseable (including
+// an empty string) as the wildcard value zero.
+func atoiOrZero(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Health tracks the liveness signals that back /healthz and /readyz.
+type Health struct {
+	mu sync.RWMutex
+
+	relayConnected     map[string]bool
+	couchbaseEnabled   bool
+	couchbaseConnected bool
+}
+
+// NewHealth creates a Health tracker. couchbaseEnabled should match
+// Configuration.CouchbaseCache, so Readyz only considers Couchbase
+// connectivity when the relay actually depends on it.
+func NewHealth(couchbaseEnabled bool) *Health {
+	return &Health{couchbaseEnabled: couchbaseEnabled, relayConnected: make(map[string]bool)}
+}
+
+// SetRelayConnected records whether the ZMQ SUB socket for a single
+// relay URL is currently connected, as reported by that relay's
+// relay.Supervisor on connect, disconnect, and stale-reconnect. Readyz
+// reports ready as long as at least one relay is connected.
+func (h *Health) SetRelayConnected(url string, connected bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.relayConnected[url] = connected
+}
+
+// zmqConnected reports whether at least one relay is currently
+// connected. Callers must hold h.mu.
+func (h *Health) zmqConnected() bool {
+	for _, connected := range h.relayConnected {
+		if connected {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCouchbaseConnected records whether the Couchbase bucket is currently
+// reachable.
+func (h *Health) SetCouchbaseConnected(connected bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.couchbaseConnected = connected
+}
+
+// Healthz reports process liveness: we're up and able to serve HTTP.
+func (h *Health) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// Readyz reports whether the relay is actually able to do its job: the
+// ZMQ SUB socket is connected, and Couchbase is reachable if configured.
+func (h *Health) Readyz(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !h.zmqConnected() {
+		http.Error(w, "zmq not connected", http.StatusServiceUnavailable)
+		return
+	}
+	if h.couchbaseEnabled && !h.couchbaseConnected {
+		http.Error(w, "couchbase not connected", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
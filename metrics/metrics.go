@@ -0,0 +1,78 @@
+// Package metrics holds the relay's Prometheus collectors and the
+// /metrics, /healthz, /readyz HTTP handlers built on top of them.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// MessagesReceived counts raw ZMQ frames received from upstream relays.
+	MessagesReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "emdr_relay_messages_received_total",
+		Help: "Raw ZMQ frames received from upstream relays.",
+	})
+
+	// DedupHits counts messages dropped because they were already seen.
+	DedupHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "emdr_relay_dedup_hits_total",
+		Help: "Messages dropped because they were already seen.",
+	})
+
+	// DedupMisses counts messages that were not duplicates and were
+	// dispatched to backends.
+	DedupMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "emdr_relay_dedup_misses_total",
+		Help: "Messages that were not duplicates and were dispatched to backends.",
+	})
+
+	// DecodeErrors counts messages that failed ZlibDecode or JSON unmarshal.
+	DecodeErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "emdr_relay_decode_errors_total",
+		Help: "Messages that failed ZlibDecode or JSON unmarshal.",
+	})
+
+	// CouchbaseWriteFailures counts errors returned by the Couchbase
+	// backend's Set calls.
+	CouchbaseWriteFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "emdr_relay_couchbase_write_failures_total",
+		Help: "Errors returned by the Couchbase backend's Set calls.",
+	})
+
+	// BytesForwarded counts raw message bytes dispatched to backends.
+	BytesForwarded = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "emdr_relay_bytes_forwarded_total",
+		Help: "Raw message bytes dispatched to backends.",
+	})
+
+	// SubscriberCount tracks currently connected WebSocket subscribers.
+	SubscriberCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "emdr_relay_websocket_subscribers",
+		Help: "Currently connected WebSocket subscribers.",
+	})
+
+	// RowsetAgeSeconds observes how old a rowset's generatedAt timestamp
+	// was by the time it was dispatched to backends.
+	RowsetAgeSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "emdr_relay_rowset_age_seconds",
+		Help:    "Age of a rowset's generatedAt timestamp when it was dispatched.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	// SubscriberDropped counts messages discarded because a WebSocket
+	// subscriber's outbound channel was full.
+	SubscriberDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "emdr_relay_websocket_subscriber_dropped_total",
+		Help: "Messages discarded because a WebSocket subscriber's outbound channel was full.",
+	})
+
+	// BackendQueueDropped counts messages dropped because a backend's
+	// worker-pool queue was full, labeled by backend name. A backend
+	// falling behind drops its own messages instead of stalling dispatch
+	// to every other backend.
+	BackendQueueDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "emdr_relay_backend_queue_dropped_total",
+		Help: "Messages dropped because a backend's worker-pool queue was full.",
+	}, []string{"backend"})
+)
@@ -0,0 +1,22 @@
+// Package relay turns raw ZMQ frames from the EMDR network into decoded
+// messages and dispatches them to a set of independent Backends.
+package relay
+
+import emdr "github.com/gtaylor/emdr-relay-go/emdr"
+
+// Backend consumes decoded EMDR messages. Implementations are registered
+// with a Reactor at startup and run independently of each other behind
+// their own worker pool, so a slow backend (e.g. Couchbase under load)
+// can't stall delivery to the others or the ZMQ receive path.
+type Backend interface {
+	// Name identifies the backend in logs and config.
+	Name() string
+	// Consume handles one decoded message. raw is the original
+	// zlib-compressed wire payload, for backends (like the ZMQ publisher)
+	// that just want to forward bytes rather than re-encode msg.
+	Consume(msg *emdr.Message, raw []byte) error
+	// Close releases any resources the backend holds (open sockets,
+	// files, etc). The Reactor calls it once per backend, after that
+	// backend's queue has drained.
+	Close() error
+}
@@ -0,0 +1,33 @@
+package relay
+
+import (
+	"sync/atomic"
+
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+)
+
+// Benchmarker is a no-op backend that only counts the messages it
+// receives, for measuring reactor/dispatch overhead in isolation from any
+// real sink.
+type Benchmarker struct {
+	count uint64
+}
+
+func (b *Benchmarker) Name() string {
+	return "benchmark"
+}
+
+// Close is a no-op: Benchmarker holds no resources of its own.
+func (b *Benchmarker) Close() error {
+	return nil
+}
+
+func (b *Benchmarker) Consume(msg *emdr.Message, raw []byte) error {
+	atomic.AddUint64(&b.count, 1)
+	return nil
+}
+
+// Count returns the number of messages consumed so far.
+func (b *Benchmarker) Count() uint64 {
+	return atomic.LoadUint64(&b.count)
+}
@@ -0,0 +1,62 @@
+package relay
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	couchbase "github.com/couchbase/go-couchbase"
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+)
+
+// CouchbaseWriter persists rowsets generated within the last hour to a
+// Couchbase bucket, keyed by "<regionID>-<typeID>-<resultType>".
+type CouchbaseWriter struct {
+	bucket *couchbase.Bucket
+}
+
+// NewCouchbaseWriter wraps an already-connected bucket.
+func NewCouchbaseWriter(bucket *couchbase.Bucket) *CouchbaseWriter {
+	return &CouchbaseWriter{bucket: bucket}
+}
+
+func (c *CouchbaseWriter) Name() string {
+	return "couchbase"
+}
+
+// Close is a no-op: the bucket is owned by main, not by the backend, and
+// go-couchbase pools its own connections.
+func (c *CouchbaseWriter) Close() error {
+	return nil
+}
+
+func (c *CouchbaseWriter) Consume(msg *emdr.Message, raw []byte) error {
+	for _, rs := range msg.OrderRowsets {
+		if err := c.writeRowset(msg, rs.RegionID, rs.TypeID, rs.GeneratedAt); err != nil {
+			return err
+		}
+	}
+	for _, rs := range msg.HistoryRowsets {
+		if err := c.writeRowset(msg, rs.RegionID, rs.TypeID, rs.GeneratedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CouchbaseWriter) writeRowset(msg *emdr.Message, regionID, typeID int, generatedAt time.Time) error {
+	if generatedAt.Unix() < time.Now().Unix()-3600 {
+		return nil
+	}
+
+	doc := EMDRDoc{regionID, typeID, int(time.Now().Unix()), msg.Envelope.UploadKeys, msg.Envelope.ResultType}
+
+	var key bytes.Buffer
+	key.WriteString(strconv.Itoa(regionID))
+	key.WriteString("-")
+	key.WriteString(strconv.Itoa(typeID))
+	key.WriteString("-")
+	key.WriteString(msg.Envelope.ResultType)
+
+	return c.bucket.Set(key.String(), 0, doc)
+}
@@ -0,0 +1,60 @@
+package relay
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+)
+
+// FileRotator appends each raw message to a file named for the current
+// UTC date under dir, rotating to a new file at midnight.
+type FileRotator struct {
+	dir string
+
+	mu      sync.Mutex
+	day     string
+	current *os.File
+}
+
+// NewFileRotator writes rotated log files into dir.
+func NewFileRotator(dir string) *FileRotator {
+	return &FileRotator{dir: dir}
+}
+
+func (f *FileRotator) Name() string {
+	return "file-rotation"
+}
+
+func (f *FileRotator) Consume(msg *emdr.Message, raw []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	day := time.Now().UTC().Format("2006-01-02")
+	if day != f.day {
+		if f.current != nil {
+			f.current.Close()
+		}
+		current, err := os.OpenFile(fmt.Sprintf("%s/%s.log", f.dir, day), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		f.current = current
+		f.day = day
+	}
+
+	_, err := f.current.Write(append(raw, '\n'))
+	return err
+}
+
+// Close closes the currently open rotated file, if any.
+func (f *FileRotator) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.current != nil {
+		return f.current.Close()
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+)
+
+// StdoutJSON writes each decoded message to stdout as a single line of
+// JSON. Mainly useful for local debugging.
+type StdoutJSON struct{}
+
+func (StdoutJSON) Name() string {
+	return "stdout-json"
+}
+
+// Close is a no-op: StdoutJSON holds no resources of its own.
+func (StdoutJSON) Close() error {
+	return nil
+}
+
+func (StdoutJSON) Consume(msg *emdr.Message, raw []byte) error {
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encoded))
+	return nil
+}
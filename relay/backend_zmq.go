@@ -0,0 +1,38 @@
+package relay
+
+import (
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+	zmq "github.com/pebbe/zmq4"
+)
+
+// ZMQPublisher re-publishes the raw (still zlib-compressed) message on a
+// ZMQ PUB socket, in the same wire format the relay received it in.
+type ZMQPublisher struct {
+	socket *zmq.Socket
+}
+
+// NewZMQPublisher binds a PUB socket on addr (e.g. "tcp://*:8050").
+func NewZMQPublisher(addr string) (*ZMQPublisher, error) {
+	socket, err := zmq.NewSocket(zmq.PUB)
+	if err != nil {
+		return nil, err
+	}
+	if err := socket.Bind(addr); err != nil {
+		return nil, err
+	}
+	return &ZMQPublisher{socket: socket}, nil
+}
+
+func (z *ZMQPublisher) Name() string {
+	return "zmq"
+}
+
+func (z *ZMQPublisher) Consume(msg *emdr.Message, raw []byte) error {
+	_, err := z.socket.SendBytes(raw, 0)
+	return err
+}
+
+// Close shuts down the underlying PUB socket.
+func (z *ZMQPublisher) Close() error {
+	return z.socket.Close()
+}
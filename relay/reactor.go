@@ -0,0 +1,159 @@
+package relay
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+	"log/slog"
+	"sync"
+	"time"
+
+	dedup "github.com/gtaylor/emdr-relay-go/dedup"
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+	metrics "github.com/gtaylor/emdr-relay-go/metrics"
+)
+
+// WorkerPoolSize bounds how many messages a single backend can process
+// concurrently. A backend that falls behind backs up its own queue rather
+// than the ZMQ receive path.
+const WorkerPoolSize = 4
+
+// QueueSize is how many decoded messages a backend's worker pool will
+// buffer before Dispatch starts dropping messages for it.
+const QueueSize = 256
+
+type job struct {
+	msg *emdr.Message
+	raw []byte
+}
+
+type registeredBackend struct {
+	backend Backend
+	queue   chan job
+	wg      sync.WaitGroup
+}
+
+// Reactor receives raw ZMQ frames, dedups and decodes them once, and
+// dispatches the result to every registered Backend via a bounded worker
+// pool per backend.
+type Reactor struct {
+	backends []*registeredBackend
+	dedup    dedup.Store
+}
+
+// NewReactor creates a Reactor backed by the given dedup.Store.
+func NewReactor(store dedup.Store) *Reactor {
+	return &Reactor{dedup: store}
+}
+
+// Register adds a Backend to the reactor. Must be called before Start.
+func (r *Reactor) Register(b Backend) {
+	r.backends = append(r.backends, &registeredBackend{
+		backend: b,
+		queue:   make(chan job, QueueSize),
+	})
+}
+
+// Start launches each backend's worker pool. Safe to call once, after all
+// backends have been Registered.
+func (r *Reactor) Start() {
+	for _, rb := range r.backends {
+		for i := 0; i < WorkerPoolSize; i++ {
+			rb.wg.Add(1)
+			go r.work(rb)
+		}
+	}
+}
+
+// Stop closes each backend's queue, waits for its workers to drain, and
+// then closes the backend itself.
+func (r *Reactor) Stop() {
+	for _, rb := range r.backends {
+		close(rb.queue)
+	}
+	for _, rb := range r.backends {
+		rb.wg.Wait()
+	}
+	for _, rb := range r.backends {
+		if err := rb.backend.Close(); err != nil {
+			slog.Error("backend close failed", "backend", rb.backend.Name(), "err", err)
+		}
+	}
+}
+
+func (r *Reactor) work(rb *registeredBackend) {
+	defer rb.wg.Done()
+	for j := range rb.queue {
+		if err := rb.backend.Consume(j.msg, j.raw); err != nil {
+			if rb.backend.Name() == "couchbase" {
+				metrics.CouchbaseWriteFailures.Inc()
+			}
+			slog.Error("backend error", "backend", rb.backend.Name(), "err", err)
+		}
+	}
+}
+
+// Dispatch hashes raw for dedup, decodes it if it's not a repeat, and fans
+// the decoded message out to every registered backend's queue with a
+// non-blocking send, so one backend falling behind can't stall delivery
+// to the others or to the caller. duplicate reports whether raw had
+// already been seen.
+func (r *Reactor) Dispatch(raw []byte) (duplicate bool, err error) {
+	metrics.MessagesReceived.Inc()
+
+	seen, err := r.dedup.SeenOrMark(dedup.HashKey(raw))
+	if err != nil {
+		return false, err
+	}
+	if seen {
+		metrics.DedupHits.Inc()
+		return true, nil
+	}
+	metrics.DedupMisses.Inc()
+
+	decoded, err := ZlibDecode(raw)
+	if err != nil {
+		metrics.DecodeErrors.Inc()
+		return false, err
+	}
+	msg, err := emdr.Decode(decoded)
+	if err != nil {
+		metrics.DecodeErrors.Inc()
+		return false, err
+	}
+
+	metrics.BytesForwarded.Add(float64(len(raw)))
+	for _, rowset := range msg.OrderRowsets {
+		metrics.RowsetAgeSeconds.Observe(time.Since(rowset.GeneratedAt).Seconds())
+	}
+	for _, rowset := range msg.HistoryRowsets {
+		metrics.RowsetAgeSeconds.Observe(time.Since(rowset.GeneratedAt).Seconds())
+	}
+
+	// Each backend's send is independent and non-blocking: a backend whose
+	// queue is full drops this message rather than stalling dispatch to
+	// every backend registered after it (and, in turn, the ZMQ receive
+	// path feeding Dispatch).
+	for _, rb := range r.backends {
+		select {
+		case rb.queue <- job{msg: msg, raw: raw}:
+		default:
+			metrics.BackendQueueDropped.WithLabelValues(rb.backend.Name()).Inc()
+			slog.Warn("backend queue full, dropping message", "backend", rb.backend.Name())
+		}
+	}
+	return false, nil
+}
+
+// ZlibDecode inflates a zlib-compressed EMDR payload.
+func ZlibDecode(encoded []byte) (decoded []byte, err error) {
+	b := bytes.NewReader(encoded)
+	pipeline, err := zlib.NewReader(b)
+
+	if err == nil {
+		defer pipeline.Close()
+		decoded, err = ioutil.ReadAll(pipeline)
+	}
+
+	return
+}
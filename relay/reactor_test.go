@@ -0,0 +1,144 @@
+package relay
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+)
+
+// memStore is a minimal in-memory dedup.Store for tests, so reactor tests
+// don't depend on the bloom filter's timing-sensitive rotation.
+type memStore struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{seen: make(map[string]bool)}
+}
+
+func (s *memStore) SeenOrMark(key []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := string(key)
+	if s.seen[k] {
+		return true, nil
+	}
+	s.seen[k] = true
+	return false, nil
+}
+
+func (s *memStore) Close() error { return nil }
+
+// recordingBackend records every message it's asked to Consume.
+type recordingBackend struct {
+	mu       sync.Mutex
+	consumed int
+}
+
+func (b *recordingBackend) Name() string { return "recording" }
+
+func (b *recordingBackend) Close() error { return nil }
+
+func (b *recordingBackend) Consume(msg *emdr.Message, raw []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consumed++
+	return nil
+}
+
+func (b *recordingBackend) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.consumed
+}
+
+func zlibCompress(t *testing.T, payload string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write([]byte(payload)); err != nil {
+		t.Fatalf("zlib write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("zlib close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func ordersPayload(regionID int) string {
+	return fmt.Sprintf(`{
+		"resultType": "orders",
+		"columns": ["price", "volRemaining", "range", "orderID", "volEntered", "minVolume", "bid", "issueDate", "duration", "stationID"],
+		"rowsets": [{
+			"regionID": %d,
+			"typeID": 34,
+			"rows": [[5.95, 1000, "region", 1, 1000, 1, false, "2026-07-25 12:00:00", 90, 60003760]]
+		}]
+	}`, regionID)
+}
+
+func TestDispatchDedupsRepeatedFrames(t *testing.T) {
+	r := NewReactor(newMemStore())
+	backend := &recordingBackend{}
+	r.Register(backend)
+	r.Start()
+	defer r.Stop()
+
+	raw := zlibCompress(t, ordersPayload(1))
+
+	duplicate, err := r.Dispatch(raw)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if duplicate {
+		t.Fatal("expected first Dispatch of a frame to not be a duplicate")
+	}
+
+	duplicate, err = r.Dispatch(raw)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if !duplicate {
+		t.Fatal("expected second Dispatch of the same frame to be a duplicate")
+	}
+
+	deadline := time.After(time.Second)
+	for backend.count() != 1 {
+		select {
+		case <-deadline:
+			t.Fatalf("backend consumed %d messages after 1s, want 1", backend.count())
+		default:
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestDispatchDoesNotBlockOnFullBackendQueue(t *testing.T) {
+	r := NewReactor(newMemStore())
+	r.Register(&recordingBackend{})
+	// Deliberately not calling Start: nothing drains the backend's queue,
+	// so sends past QueueSize must be dropped, not block.
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < QueueSize+10; i++ {
+			raw := zlibCompress(t, ordersPayload(i))
+			if _, err := r.Dispatch(raw); err != nil {
+				t.Errorf("Dispatch: %v", err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Dispatch blocked instead of dropping messages for a full backend queue")
+	}
+}
@@ -0,0 +1,111 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	zmq "github.com/pebbe/zmq4"
+)
+
+// StaleAfter is how long a relay URL can go without sending a message
+// before a Supervisor tears down and reconnects its SUB socket. This
+// replaces the old relay-wide 12-hour hard exit with targeted,
+// per-relay recovery.
+const StaleAfter = 5 * time.Minute
+
+// recvTimeout bounds how long a single Recv call blocks, so Run can
+// notice staleness and context cancellation without a dedicated poller.
+const recvTimeout = time.Second
+
+// Supervisor owns one upstream relay's ZMQ SUB connection. It reconnects
+// just that socket when it goes stale or errors out, rather than letting
+// the whole process exit and restart.
+type Supervisor struct {
+	url         string
+	frames      chan<- []byte
+	onConnected func(connected bool)
+
+	lastMessage atomic.Int64 // unix seconds
+}
+
+// NewSupervisor creates a Supervisor for url. Frames received from this
+// relay are sent to frames. onConnected, if non-nil, is called with true
+// once the SUB socket is connected and subscribed, and with false when
+// that connection is torn down (on error, on staleness, or on shutdown)
+// so callers can track per-relay liveness (see metrics.Health).
+func NewSupervisor(url string, frames chan<- []byte, onConnected func(connected bool)) *Supervisor {
+	return &Supervisor{url: url, frames: frames, onConnected: onConnected}
+}
+
+// Run connects to the relay and forwards frames until ctx is canceled,
+// reconnecting automatically whenever the connection goes stale or
+// errors out.
+func (s *Supervisor) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := s.runOnce(ctx); err != nil {
+			slog.Error("relay connection lost, reconnecting", "url", s.url, "err", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (s *Supervisor) setConnected(connected bool) {
+	if s.onConnected != nil {
+		s.onConnected(connected)
+	}
+}
+
+func (s *Supervisor) runOnce(ctx context.Context) error {
+	receiver, err := zmq.NewSocket(zmq.SUB)
+	if err != nil {
+		return err
+	}
+	defer receiver.Close()
+
+	if err := receiver.Connect(s.url); err != nil {
+		return err
+	}
+	if err := receiver.SetSubscribe(""); err != nil {
+		return err
+	}
+	if err := receiver.SetRcvtimeo(recvTimeout); err != nil {
+		return err
+	}
+
+	s.lastMessage.Store(time.Now().Unix())
+	s.setConnected(true)
+	defer s.setConnected(false)
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		msg, err := receiver.Recv(0)
+		if err != nil {
+			if errno, ok := err.(zmq.Errno); ok && errno == zmq.Errno(syscall.EAGAIN) {
+				if s.stale() {
+					return fmt.Errorf("no messages for %s", StaleAfter)
+				}
+				continue
+			}
+			return err
+		}
+
+		s.lastMessage.Store(time.Now().Unix())
+		select {
+		case s.frames <- []byte(msg):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *Supervisor) stale() bool {
+	last := time.Unix(s.lastMessage.Load(), 0)
+	return time.Since(last) > StaleAfter
+}
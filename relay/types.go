@@ -0,0 +1,15 @@
+package relay
+
+import (
+	emdr "github.com/gtaylor/emdr-relay-go/emdr"
+)
+
+// EMDRDoc is the reduced form of a decoded rowset persisted to Couchbase
+// by the CouchbaseWriter backend.
+type EMDRDoc struct {
+	Region     int              `json:"region"`
+	ItemID     int              `json:"ItemID"`
+	InsertTime int              `json:"InsertTime"`
+	UploadKeys []emdr.UploadKey `json:"uploadKeys"`
+	ResultType string           `json:"resultType"`
+}
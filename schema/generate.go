@@ -0,0 +1,3 @@
+package schema
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative emdr.proto